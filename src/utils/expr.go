@@ -0,0 +1,207 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+	"github.com/mitchellh/mapstructure"
+)
+
+// exprPrefix marks a config string as an expression to be compiled and evaluated rather
+// than used verbatim, e.g. `count: "${expr: min(cpuCount*4, 32)}"`.
+const exprPrefix = "${expr:"
+
+// Program is a compiled expression ready for repeated evaluation.
+type Program struct {
+	source string
+	prog   *vm.Program
+}
+
+// programCache holds programs already compiled via Compile/MustCompile, keyed by source
+// string, so that jobs referencing the same expression repeatedly don't pay to recompile it.
+var (
+	programCacheMu sync.Mutex
+	programCache   = map[string]*Program{}
+)
+
+// envAllowlist names the only environment variables the `env(...)` expression identifier may
+// read. It defaults to empty: job configs typically come from a remote, operator-authored
+// manifest, so expressions must not be able to read out the whole process environment
+// (tokens, credentials, ...) - only vars the embedding app explicitly opted to expose.
+var (
+	envAllowlistMu sync.RWMutex
+	envAllowlist   = map[string]struct{}{}
+)
+
+// SetEnvAllowlist replaces the set of environment variables visible to `${expr: ...}`
+// expressions via env(name). Call with no arguments to expose nothing (the default).
+func SetEnvAllowlist(names ...string) {
+	allowed := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		allowed[name] = struct{}{}
+	}
+
+	envAllowlistMu.Lock()
+	envAllowlist = allowed
+	envAllowlistMu.Unlock()
+}
+
+// envLookup returns the value of name if it's on the allowlist, and "" otherwise.
+func envLookup(name string) string {
+	envAllowlistMu.RLock()
+	_, ok := envAllowlist[name]
+	envAllowlistMu.RUnlock()
+
+	if !ok {
+		return ""
+	}
+
+	value, _ := os.LookupEnv(name)
+
+	return value
+}
+
+// env is the base set of identifiers available to every expression, on top of whatever
+// vars the caller supplies.
+func env(vars map[string]any) map[string]any {
+	base := map[string]any{
+		"env": envLookup,
+		"now": time.Now,
+		"min": mathMin,
+		"max": mathMax,
+	}
+
+	if hostname, err := os.Hostname(); err == nil {
+		base["hostname"] = hostname
+	}
+
+	for k, v := range vars {
+		base[k] = v
+	}
+
+	return base
+}
+
+func mathMin(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+func mathMax(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
+// maxExprNodes caps the size of the AST a single expression may compile to, so a
+// pathological expression in a config can't blow up compile/eval time. env() never exposes
+// file or network functions to begin with, so there's nothing else to sandbox.
+const maxExprNodes = 500
+
+// Compile compiles expr into a reusable Program, capping the expression's complexity via
+// expr.MaxNodes.
+func Compile(source string, vars map[string]any) (*Program, error) {
+	programCacheMu.Lock()
+	if p, ok := programCache[source]; ok {
+		programCacheMu.Unlock()
+		return p, nil
+	}
+	programCacheMu.Unlock()
+
+	prog, err := expr.Compile(
+		source,
+		expr.Env(env(vars)),
+		expr.AllowUndefinedVariables(),
+		expr.MaxNodes(maxExprNodes),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("compiling expression %q: %w", source, err)
+	}
+
+	p := &Program{source: source, prog: prog}
+
+	programCacheMu.Lock()
+	programCache[source] = p
+	programCacheMu.Unlock()
+
+	return p, nil
+}
+
+// MustCompile is like Compile but panics if the expression is invalid. Meant for use with
+// expressions known at init time.
+func MustCompile(source string, vars map[string]any) *Program {
+	p, err := Compile(source, vars)
+	if err != nil {
+		panic(err)
+	}
+
+	return p
+}
+
+// Eval runs a compiled program against the given vars, merged on top of the base identifiers
+// (env, now, min, max, hostname); vars passed to Compile are not carried over. ctx is honored on a best-effort basis: evaluation itself is not
+// preemptible, so long-running expressions should be avoided in caller-supplied vars.
+func Eval(ctx context.Context, program *Program, vars map[string]any) (any, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	result, err := expr.Run(program.prog, env(vars))
+	if err != nil {
+		return nil, fmt.Errorf("evaluating expression %q: %w", program.source, err)
+	}
+
+	return result, nil
+}
+
+// exprDecodeHook compiles and evaluates any string field of the form `${expr: ...}` found
+// while decoding a config, so jobs can compute values like rate limits at decode time. vars
+// are made available to the expression alongside env/now/hostname - this is how callers
+// plug in things like the request's own `cpuCount` example.
+//
+// The result is returned as-is (not stringified) so WeaklyTypedInput can coerce it into
+// whatever kind the destination field actually is, e.g. an int field. Note that since this
+// hook only runs once, at Decode time, values depending on something that changes between
+// calls (like `now`) are baked in then and won't be re-evaluated later; callers that need
+// per-invocation re-evaluation should keep the raw expression string and call Compile/Eval
+// themselves at the point of use instead of going through Decode.
+func exprDecodeHook(vars map[string]any) mapstructure.DecodeHookFunc {
+	return func(from reflect.Type, to reflect.Type, data any) (any, error) {
+		if from.Kind() != reflect.String {
+			return data, nil
+		}
+
+		raw, ok := data.(string)
+		if !ok || !strings.HasPrefix(raw, exprPrefix) || !strings.HasSuffix(raw, "}") {
+			return data, nil
+		}
+
+		source := strings.TrimSuffix(strings.TrimPrefix(raw, exprPrefix), "}")
+
+		program, err := Compile(source, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := Eval(context.Background(), program, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		return result, nil
+	}
+}
@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"context"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	program := MustCompile("min(cpuCount*4, 32)", map[string]any{"cpuCount": 2})
+
+	result, err := Eval(context.Background(), program, map[string]any{"cpuCount": 2})
+	if err != nil {
+		t.Fatalf("Eval returned error: %v", err)
+	}
+
+	if result != float64(8) {
+		t.Fatalf("expected 8, got %v", result)
+	}
+}
+
+func TestDecode_ExprField(t *testing.T) {
+	type target struct {
+		Count int
+	}
+
+	var out target
+
+	err := Decode(
+		map[string]any{"count": "${expr: min(cpuCount*4, 32)}"},
+		&out,
+		WithExprVars(map[string]any{"cpuCount": 2}),
+	)
+	if err != nil {
+		t.Fatalf("Decode returned error: %v", err)
+	}
+
+	if out.Count != 8 {
+		t.Fatalf("expected Count == 8, got %d", out.Count)
+	}
+}
+
+func TestDecode_ExprField_ClampedAtCap(t *testing.T) {
+	type target struct {
+		Count int
+	}
+
+	var out target
+
+	err := Decode(
+		map[string]any{"count": "${expr: min(cpuCount*4, 32)}"},
+		&out,
+		WithExprVars(map[string]any{"cpuCount": 100}),
+	)
+	if err != nil {
+		t.Fatalf("Decode returned error: %v", err)
+	}
+
+	if out.Count != 32 {
+		t.Fatalf("expected Count == 32, got %d", out.Count)
+	}
+}
+
+func TestDecode_ExprField_NoVars(t *testing.T) {
+	type target struct {
+		Enabled string
+	}
+
+	var out target
+
+	if err := Decode(map[string]any{"enabled": "${expr: hostname != \"\"}"}, &out); err != nil {
+		t.Fatalf("Decode returned error: %v", err)
+	}
+
+	if out.Enabled != "1" {
+		t.Fatalf("expected Enabled == \"1\", got %q", out.Enabled)
+	}
+}
+
+func TestEnv_DeniedByDefault(t *testing.T) {
+	SetEnvAllowlist()
+	t.Cleanup(func() { SetEnvAllowlist() })
+
+	t.Setenv("DB1000N_EXPR_TEST_SECRET", "top-secret")
+
+	program := MustCompile(`env("DB1000N_EXPR_TEST_SECRET")`, nil)
+
+	result, err := Eval(context.Background(), program, nil)
+	if err != nil {
+		t.Fatalf("Eval returned error: %v", err)
+	}
+
+	if result != "" {
+		t.Fatalf("expected env() to deny an unlisted var by default, got %q", result)
+	}
+}
+
+func TestEnv_AllowlistedVarIsReadable(t *testing.T) {
+	SetEnvAllowlist("DB1000N_EXPR_TEST_ALLOWED")
+	t.Cleanup(func() { SetEnvAllowlist() })
+
+	t.Setenv("DB1000N_EXPR_TEST_ALLOWED", "fine")
+	t.Setenv("DB1000N_EXPR_TEST_SECRET", "top-secret")
+
+	program := MustCompile(`env("DB1000N_EXPR_TEST_ALLOWED")`, nil)
+
+	result, err := Eval(context.Background(), program, nil)
+	if err != nil {
+		t.Fatalf("Eval returned error: %v", err)
+	}
+
+	if result != "fine" {
+		t.Fatalf("expected allowlisted var to resolve to %q, got %q", "fine", result)
+	}
+
+	secretProgram := MustCompile(`env("DB1000N_EXPR_TEST_SECRET")`, nil)
+
+	result, err = Eval(context.Background(), secretProgram, nil)
+	if err != nil {
+		t.Fatalf("Eval returned error: %v", err)
+	}
+
+	if result != "" {
+		t.Fatalf("expected non-allowlisted var to stay hidden, got %q", result)
+	}
+}
@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsimple"
+)
+
+// fieldAliases walks output's (possibly nested, pointer-to-) struct type and, for every
+// field carrying a toml:"..." or hcl:",..." tag, records its folded tag value as an alias
+// of the field name - so Decode's MatchName can also match configs written against those
+// formats even though mapstructure itself only understands its own tag.
+func fieldAliases(output any, fold func(string) string) map[string][]string {
+	aliases := map[string][]string{}
+
+	t := reflect.TypeOf(output)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == nil || t.Kind() != reflect.Struct {
+		return aliases
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		for _, tagName := range []string{"toml", "hcl"} {
+			tag, ok := field.Tag.Lookup(tagName)
+			if !ok {
+				continue
+			}
+
+			name := strings.SplitN(tag, ",", 2)[0]
+			if name == "" || name == "-" {
+				continue
+			}
+
+			aliases[field.Name] = append(aliases[field.Name], fold(name))
+		}
+	}
+
+	return aliases
+}
+
+// unmarshalHCL decodes HCL input into output via hclsimple, which requires a filename for
+// diagnostics only - the exact name doesn't matter since it's never read from disk.
+func unmarshalHCL(input []byte, output any) error {
+	if err := hclsimple.Decode("config.hcl", input, nil, output); err != nil {
+		var diags hcl.Diagnostics
+		if diags, _ = err.(hcl.Diagnostics); diags != nil {
+			return fmt.Errorf("decoding hcl: %w", diags)
+		}
+
+		return fmt.Errorf("decoding hcl: %w", err)
+	}
+
+	return nil
+}
+
+// UnmarshalStream reads documents out of r according to format and invokes fn with the raw
+// bytes of each one, without buffering the whole input - needed for large job manifests.
+// format "yaml" splits on "---" document separators; format "ndjson" yields each top-level
+// JSON value in turn (one per line in well-formed NDJSON).
+func UnmarshalStream(r io.Reader, format string, fn func([]byte) error) error {
+	switch format {
+	case "yaml":
+		return unmarshalYAMLStream(r, fn)
+	case "ndjson":
+		return unmarshalNDJSONStream(r, fn)
+	default:
+		return fmt.Errorf("unknown stream format: %v", format)
+	}
+}
+
+func unmarshalYAMLStream(r io.Reader, fn func([]byte) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+
+	var doc bytes.Buffer
+
+	flush := func() error {
+		if bytes.TrimSpace(doc.Bytes()) == nil {
+			doc.Reset()
+			return nil
+		}
+
+		defer doc.Reset()
+
+		return fn(doc.Bytes())
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if bytes.Equal(bytes.TrimSpace([]byte(line)), []byte("---")) {
+			if err := flush(); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		doc.WriteString(line)
+		doc.WriteByte('\n')
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return flush()
+}
+
+func unmarshalNDJSONStream(r io.Reader, fn func([]byte) error) error {
+	decoder := json.NewDecoder(r)
+
+	for {
+		var raw json.RawMessage
+
+		if err := decoder.Decode(&raw); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+
+			return err
+		}
+
+		if err := fn(raw); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnmarshal_TOML(t *testing.T) {
+	type target struct {
+		Name string
+		Rate int
+	}
+
+	var out target
+
+	if err := Unmarshal([]byte("name = \"foo\"\nrate = 3\n"), &out, "toml"); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+
+	if out.Name != "foo" || out.Rate != 3 {
+		t.Fatalf("unexpected result: %+v", out)
+	}
+}
+
+func TestUnmarshal_HCL(t *testing.T) {
+	type target struct {
+		Name string `hcl:"name"`
+		Rate int    `hcl:"rate"`
+	}
+
+	var out target
+
+	if err := Unmarshal([]byte("name = \"foo\"\nrate = 3\n"), &out, "hcl"); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+
+	if out.Name != "foo" || out.Rate != 3 {
+		t.Fatalf("unexpected result: %+v", out)
+	}
+}
+
+func TestUnmarshal_UnknownFormat(t *testing.T) {
+	var out map[string]any
+
+	if err := Unmarshal([]byte("{}"), &out, "xml"); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+}
+
+func TestUnmarshalStream_YAML(t *testing.T) {
+	input := "a: 1\n---\nb: 2\n---\n\n---\nc: 3\n"
+
+	var docs []string
+
+	err := UnmarshalStream(strings.NewReader(input), "yaml", func(doc []byte) error {
+		docs = append(docs, strings.TrimSpace(string(doc)))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UnmarshalStream returned error: %v", err)
+	}
+
+	want := []string{"a: 1", "b: 2", "c: 3"}
+	if len(docs) != len(want) {
+		t.Fatalf("expected %d documents, got %d: %v", len(want), len(docs), docs)
+	}
+
+	for i, doc := range docs {
+		if doc != want[i] {
+			t.Fatalf("document %d: expected %q, got %q", i, want[i], doc)
+		}
+	}
+}
+
+func TestUnmarshalStream_NDJSON(t *testing.T) {
+	input := `{"a":1}` + "\n" + `{"b":2}` + "\n"
+
+	var docs []string
+
+	err := UnmarshalStream(strings.NewReader(input), "ndjson", func(doc []byte) error {
+		docs = append(docs, string(doc))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UnmarshalStream returned error: %v", err)
+	}
+
+	if len(docs) != 2 {
+		t.Fatalf("expected 2 documents, got %d: %v", len(docs), docs)
+	}
+}
+
+func TestDecode_TOMLTagAlias(t *testing.T) {
+	type target struct {
+		RateLimit int `toml:"requests_per_second"`
+	}
+
+	var out target
+
+	if err := Decode(map[string]any{"requests_per_second": 5}, &out); err != nil {
+		t.Fatalf("Decode returned error: %v", err)
+	}
+
+	if out.RateLimit != 5 {
+		t.Fatalf("expected RateLimit == 5, got %d", out.RateLimit)
+	}
+}
@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// GroupOpts configures a Group returned by NewGroup.
+type GroupOpts struct {
+	// Limit caps the number of goroutines running concurrently. Zero means unlimited.
+	Limit int
+
+	// CancelOnPanic cancels the group's context as soon as any job panics, in addition to
+	// the usual cancel-on-first-error behavior.
+	CancelOnPanic bool
+
+	Logger *zap.Logger
+}
+
+// Group runs named jobs under a shared context, recovering panics itself (logged the way
+// PanicHandler logs them) and converting them into errors, and collecting every failure instead of just the first one. It's the
+// structured-concurrency counterpart to the ad-hoc goroutines the job runner used to spawn.
+type Group struct {
+	opts   GroupOpts
+	ctx    context.Context
+	cancel context.CancelFunc
+	sem    chan struct{}
+
+	wg sync.WaitGroup
+
+	mu   sync.Mutex
+	errs []error
+}
+
+// NewGroup returns a Group and a context derived from parentCtx that is cancelled once the
+// first job fails (or panics, if CancelOnPanic is set) or when Wait returns.
+func NewGroup(parentCtx context.Context, opts GroupOpts) (*Group, context.Context) {
+	ctx, cancel := context.WithCancel(parentCtx)
+
+	var sem chan struct{}
+	if opts.Limit > 0 {
+		sem = make(chan struct{}, opts.Limit)
+	}
+
+	g := &Group{opts: opts, ctx: ctx, cancel: cancel, sem: sem}
+
+	return g, ctx
+}
+
+// Go runs fn in its own goroutine with panics recovered into errors, blocking until a concurrency slot is
+// available if Limit was set. name is attached to any resulting error for diagnostics.
+func (g *Group) Go(name string, fn func(ctx context.Context) error) {
+	if g.sem != nil {
+		select {
+		case g.sem <- struct{}{}:
+		case <-g.ctx.Done():
+			g.addErr(fmt.Errorf("%s: %w", name, g.ctx.Err()))
+			return
+		}
+	}
+
+	g.wg.Add(1)
+
+	go func() {
+		defer g.wg.Done()
+
+		if g.sem != nil {
+			defer func() { <-g.sem }()
+		}
+
+		g.run(name, fn)
+	}()
+}
+
+func (g *Group) run(name string, fn func(ctx context.Context) error) {
+	defer func() {
+		if err := recover(); err != nil {
+			if g.opts.Logger != nil {
+				g.opts.Logger.Error("caught panic, recovering", zap.String("job", name), zap.Any("err", err))
+			}
+
+			g.addErr(fmt.Errorf("job %q panicked: %v", name, err))
+
+			if g.opts.CancelOnPanic {
+				g.cancel()
+			}
+		}
+	}()
+
+	if err := fn(g.ctx); err != nil {
+		g.addErr(fmt.Errorf("job %q: %w", name, err))
+		g.cancel()
+	}
+}
+
+// GoEvery runs fn on every tick of interval, under the same panic/log semantics as Go, until
+// the group's context is cancelled. It replaces the hand-rolled ticker loops previously
+// scattered across the job runner.
+func (g *Group) GoEvery(name string, interval time.Duration, fn func(ctx context.Context) error) {
+	g.Go(name, func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				if err := fn(ctx); err != nil {
+					return err
+				}
+			}
+		}
+	})
+}
+
+func (g *Group) addErr(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.errs = append(g.errs, err)
+}
+
+// Wait blocks until every job started with Go/GoEvery has returned, then returns every
+// collected failure joined together, or nil if all jobs succeeded.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return errors.Join(g.errs...)
+}
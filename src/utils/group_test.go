@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGroup_CollectsEveryError(t *testing.T) {
+	g, ctx := NewGroup(context.Background(), GroupOpts{})
+
+	g.Go("job-a", func(ctx context.Context) error { return errors.New("boom a") })
+	g.Go("job-b", func(ctx context.Context) error { return errors.New("boom b") })
+	g.Go("job-c", func(ctx context.Context) error { return nil })
+
+	err := g.Wait()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if !strings.Contains(err.Error(), "boom a") || !strings.Contains(err.Error(), "boom b") {
+		t.Fatalf("expected both failures in joined error, got: %v", err)
+	}
+
+	if ctx.Err() == nil {
+		t.Fatal("expected group context to be cancelled after a job failed")
+	}
+}
+
+func TestGroup_PanicIsRecoveredAndReported(t *testing.T) {
+	g, _ := NewGroup(context.Background(), GroupOpts{CancelOnPanic: true})
+
+	g.Go("job-panics", func(ctx context.Context) error {
+		panic("oh no")
+	})
+
+	err := g.Wait()
+	if err == nil || !strings.Contains(err.Error(), "job-panics") {
+		t.Fatalf("expected panic to surface as an error naming the job, got: %v", err)
+	}
+}
+
+func TestGroup_LimitBoundsConcurrency(t *testing.T) {
+	g, _ := NewGroup(context.Background(), GroupOpts{Limit: 2})
+
+	release := make(chan struct{})
+
+	var current, maxConcurrent atomic.Int64
+
+	// g.Go blocks its caller once Limit jobs are in flight, so each call is spawned from its
+	// own goroutine rather than issued in a tight loop - otherwise the launching loop itself
+	// would deadlock waiting for a semaphore slot that release (below) is meant to free up.
+	// launched tracks when each of those calls has returned (i.e. is past the semaphore
+	// acquire and has registered with the group's internal WaitGroup), so Wait below is never
+	// racing a late Go call's wg.Add.
+	var launched sync.WaitGroup
+
+	launched.Add(5)
+
+	for i := 0; i < 5; i++ {
+		go func() {
+			defer launched.Done()
+
+			g.Go("job", func(ctx context.Context) error {
+				n := current.Add(1)
+				defer current.Add(-1)
+
+				for {
+					observed := maxConcurrent.Load()
+					if n <= observed || maxConcurrent.CompareAndSwap(observed, n) {
+						break
+					}
+				}
+
+				<-release
+				return nil
+			})
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	launched.Wait()
+
+	if err := g.Wait(); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+
+	if maxConcurrent.Load() > 2 {
+		t.Fatalf("expected at most 2 concurrent jobs, observed %d", maxConcurrent.Load())
+	}
+}
+
+func TestGroup_GoEveryStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	g, _ := NewGroup(ctx, GroupOpts{})
+
+	var ticks int
+
+	g.GoEvery("every", 10*time.Millisecond, func(ctx context.Context) error {
+		ticks++
+		return nil
+	})
+
+	time.Sleep(35 * time.Millisecond)
+	cancel()
+
+	if err := g.Wait(); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+
+	if ticks == 0 {
+		t.Fatal("expected GoEvery to have run at least once")
+	}
+}
@@ -0,0 +1,283 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/drone/envsubst"
+	"github.com/mitchellh/mapstructure"
+)
+
+// ValueResolver resolves a placeholder reference (the part inside `${...}`, after any scheme
+// prefix has been stripped) to its actual value, e.g. a file path to its contents.
+type ValueResolver interface {
+	// Scheme is the prefix this resolver handles, e.g. "file" for `${file:/path}`. The empty
+	// string denotes the default resolver used for plain `${VAR}` / `${VAR:-default}` refs.
+	Scheme() string
+	Resolve(ref string) (string, error)
+}
+
+// EnvResolver resolves plain `${VAR}` references against the process environment. It's the
+// default resolver and also what envsubst itself calls out to.
+type EnvResolver struct{}
+
+func (EnvResolver) Scheme() string { return "" }
+
+func (EnvResolver) Resolve(ref string) (string, error) {
+	value, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("env var %q is not set", ref)
+	}
+
+	return value, nil
+}
+
+// FileResolver resolves `${file:/path/to/token}` references to the contents of the file.
+type FileResolver struct{}
+
+func (FileResolver) Scheme() string { return "file" }
+
+func (FileResolver) Resolve(ref string) (string, error) {
+	data, err := os.ReadFile(ref)
+	if err != nil {
+		return "", fmt.Errorf("reading %q: %w", ref, err)
+	}
+
+	return string(data), nil
+}
+
+// HTTPResolver resolves `${http:https://.../token}` references by fetching the URL, caching
+// the result for TTL so repeated references to the same secret don't hit the network again.
+type HTTPResolver struct {
+	TTL    time.Duration
+	Client *http.Client
+
+	mu    sync.Mutex
+	cache map[string]httpCacheEntry
+}
+
+type httpCacheEntry struct {
+	value   string
+	expires time.Time
+}
+
+// defaultHTTPResolverTimeout bounds requests made by an HTTPResolver with no Client set.
+// http.DefaultClient has no timeout at all, so a slow or hung secret endpoint would
+// otherwise block config loading indefinitely.
+const defaultHTTPResolverTimeout = 10 * time.Second
+
+func (r *HTTPResolver) Scheme() string { return "http" }
+
+func (r *HTTPResolver) Resolve(ref string) (string, error) {
+	r.mu.Lock()
+	if entry, ok := r.cache[ref]; ok && time.Now().Before(entry.expires) {
+		r.mu.Unlock()
+		return entry.value, nil
+	}
+	r.mu.Unlock()
+
+	client := r.Client
+	if client == nil {
+		client = &http.Client{Timeout: defaultHTTPResolverTimeout}
+	}
+
+	resp, err := client.Get(ref)
+	if err != nil {
+		return "", fmt.Errorf("fetching %q: %w", ref, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetching %q: unexpected status %s", ref, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("reading response from %q: %w", ref, err)
+	}
+
+	value := string(body)
+
+	r.mu.Lock()
+	if r.cache == nil {
+		r.cache = map[string]httpCacheEntry{}
+	}
+	r.cache[ref] = httpCacheEntry{value: value, expires: time.Now().Add(r.TTL)}
+	r.mu.Unlock()
+
+	return value, nil
+}
+
+// unmarshalOpts holds the options accumulated by UnmarshalOption values.
+type unmarshalOpts struct {
+	resolvers map[string]ValueResolver
+}
+
+// UnmarshalOption configures optional behavior of Unmarshal.
+type UnmarshalOption func(*unmarshalOpts)
+
+// WithResolvers registers ValueResolvers to expand `${scheme:ref}` (or plain `${VAR}` for the
+// default resolver) placeholders in every string field before decoding. Existing callers of
+// Unmarshal are unaffected since no resolvers run unless this option is passed.
+func WithResolvers(resolvers ...ValueResolver) UnmarshalOption {
+	return func(o *unmarshalOpts) {
+		if o.resolvers == nil {
+			o.resolvers = map[string]ValueResolver{}
+		}
+
+		for _, r := range resolvers {
+			o.resolvers[r.Scheme()] = r
+		}
+	}
+}
+
+// placeholderRef matches a `${...}` placeholder, capturing everything between the braces.
+var placeholderRef = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// expandPlaceholders resolves every `${...}` placeholder in input. Scheme-prefixed refs
+// (`${file:...}`, `${http:...}`, or any other scheme registered via WithResolvers) are
+// resolved directly against their ValueResolver. Everything else is left for envsubst, which
+// natively understands the bare `${VAR}`, `${VAR:-default}` and `${VAR:?required}` forms,
+// calling out to the default resolver (EnvResolver unless overridden) for the lookup itself.
+func expandPlaceholders(input []byte, opts unmarshalOpts) ([]byte, error) {
+	if len(opts.resolvers) == 0 {
+		return input, nil
+	}
+
+	defaultResolver, ok := opts.resolvers[""]
+	if !ok {
+		defaultResolver = EnvResolver{}
+	}
+
+	var resolveErr error
+
+	withSchemesResolved := placeholderRef.ReplaceAllStringFunc(string(input), func(match string) string {
+		scheme, ref, ok := parseSchemeRef(match)
+		if !ok || scheme == "" {
+			return match
+		}
+
+		resolver, ok := opts.resolvers[scheme]
+		if !ok {
+			return match
+		}
+
+		value, err := resolver.Resolve(ref)
+		if err != nil {
+			resolveErr = err
+			return match
+		}
+
+		return value
+	})
+	if resolveErr != nil {
+		return nil, fmt.Errorf("expanding config placeholders: %w", resolveErr)
+	}
+
+	expanded, err := envsubst.Eval(withSchemesResolved, func(name string) string {
+		value, _ := defaultResolver.Resolve(name)
+		return value
+	})
+	if err != nil {
+		return nil, fmt.Errorf("expanding config placeholders: %w", err)
+	}
+
+	return []byte(expanded), nil
+}
+
+// resolveDecodeHook expands `${scheme:ref}` placeholders in place on string, []string and
+// map[string]string fields as part of Decode, via mapstructure.DecodeHookFuncValue so it
+// sees already-typed Go values instead of raw config bytes. Only the first placeholder of
+// each string is looked at, and bare `${VAR}` refs are left as they are.
+func resolveDecodeHook(resolvers []ValueResolver) mapstructure.DecodeHookFunc {
+	byScheme := make(map[string]ValueResolver, len(resolvers))
+	for _, r := range resolvers {
+		byScheme[r.Scheme()] = r
+	}
+
+	return func(from, to reflect.Value) (any, error) {
+		if len(byScheme) == 0 {
+			return from.Interface(), nil
+		}
+
+		switch v := from.Interface().(type) {
+		case string:
+			return resolveRefString(v, byScheme)
+		case []string:
+			out := make([]string, len(v))
+
+			for i, s := range v {
+				resolved, err := resolveRefString(s, byScheme)
+				if err != nil {
+					return nil, err
+				}
+
+				out[i] = resolved
+			}
+
+			return out, nil
+		case map[string]string:
+			out := make(map[string]string, len(v))
+
+			for k, s := range v {
+				resolved, err := resolveRefString(s, byScheme)
+				if err != nil {
+					return nil, err
+				}
+
+				out[k] = resolved
+			}
+
+			return out, nil
+		default:
+			return from.Interface(), nil
+		}
+	}
+}
+
+func resolveRefString(s string, resolvers map[string]ValueResolver) (string, error) {
+	scheme, ref, ok := parseSchemeRef(s)
+	if !ok || scheme == "" {
+		return s, nil
+	}
+
+	resolver, ok := resolvers[scheme]
+	if !ok {
+		return s, nil
+	}
+
+	value, err := resolver.Resolve(ref)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Replace(s, placeholderRef.FindString(s), value, 1), nil
+}
+
+// parseSchemeRef extracts the scheme and ref out of a `${scheme:ref}` placeholder, e.g.
+// `${file:/etc/token}` -> ("file", "/etc/token", true). Plain `${VAR}` refs with no colon
+// yield scheme ""; otherwise whatever precedes the first colon is returned as the scheme,
+// registered or not, e.g. `${VAR:-default}` -> ("VAR", "-default", true).
+func parseSchemeRef(placeholder string) (scheme, ref string, ok bool) {
+	match := placeholderRef.FindStringSubmatch(placeholder)
+	if match == nil {
+		return "", "", false
+	}
+
+	body := match[1]
+
+	for i := 0; i < len(body); i++ {
+		if body[i] == ':' {
+			return body[:i], body[i+1:], true
+		}
+	}
+
+	return "", body, true
+}
@@ -0,0 +1,182 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseSchemeRef(t *testing.T) {
+	cases := []struct {
+		name        string
+		placeholder string
+		wantScheme  string
+		wantRef     string
+		wantOK      bool
+	}{
+		{"file scheme", "${file:/path/to/token}", "file", "/path/to/token", true},
+		{"http scheme with embedded colon", "${http:https://host/a:b}", "http", "https://host/a:b", true},
+		{"bare var falls through", "${VAR:-default}", "VAR", "-default", true},
+		{"no braces", "VAR", "", "", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			scheme, ref, ok := parseSchemeRef(tc.placeholder)
+			if ok != tc.wantOK {
+				t.Fatalf("ok: expected %v, got %v", tc.wantOK, ok)
+			}
+
+			if !ok {
+				return
+			}
+
+			if scheme != tc.wantScheme || ref != tc.wantRef {
+				t.Fatalf("expected (%q, %q), got (%q, %q)", tc.wantScheme, tc.wantRef, scheme, ref)
+			}
+		})
+	}
+}
+
+func TestEnvResolver(t *testing.T) {
+	t.Setenv("DB1000N_RESOLVE_TEST_VAR", "hello")
+
+	var r EnvResolver
+
+	value, err := r.Resolve("DB1000N_RESOLVE_TEST_VAR")
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+
+	if value != "hello" {
+		t.Fatalf("expected %q, got %q", "hello", value)
+	}
+
+	if _, err := r.Resolve("DB1000N_RESOLVE_TEST_VAR_UNSET"); err == nil {
+		t.Fatal("expected an error for an unset var")
+	}
+}
+
+func TestFileResolver(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("secret-token"), 0o600); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+
+	var r FileResolver
+
+	value, err := r.Resolve(path)
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+
+	if value != "secret-token" {
+		t.Fatalf("expected %q, got %q", "secret-token", value)
+	}
+}
+
+func TestHTTPResolver_CachesWithinTTL(t *testing.T) {
+	var hits int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Write([]byte("token-value"))
+	}))
+	defer server.Close()
+
+	r := &HTTPResolver{TTL: time.Minute}
+
+	for i := 0; i < 3; i++ {
+		value, err := r.Resolve(server.URL)
+		if err != nil {
+			t.Fatalf("Resolve returned error: %v", err)
+		}
+
+		if value != "token-value" {
+			t.Fatalf("expected %q, got %q", "token-value", value)
+		}
+	}
+
+	if hits != 1 {
+		t.Fatalf("expected the server to be hit once due to caching, got %d hits", hits)
+	}
+}
+
+func TestHTTPResolver_NilClientReportsDialError(t *testing.T) {
+	r := &HTTPResolver{}
+
+	if _, err := r.Resolve("http://127.0.0.1:0"); err == nil {
+		t.Fatal("expected an error dialing a closed port")
+	}
+}
+
+func TestExpandPlaceholders(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("file-contents"), 0o600); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+
+	t.Setenv("DB1000N_RESOLVE_TEST_VAR", "env-value")
+
+	input := "a: ${DB1000N_RESOLVE_TEST_VAR}\nb: ${file:" + path + "}\nc: ${MISSING_VAR:-fallback}\n"
+
+	out, err := expandPlaceholders([]byte(input), unmarshalOpts{
+		resolvers: map[string]ValueResolver{
+			"":     EnvResolver{},
+			"file": FileResolver{},
+		},
+	})
+	if err != nil {
+		t.Fatalf("expandPlaceholders returned error: %v", err)
+	}
+
+	want := "a: env-value\nb: file-contents\nc: fallback\n"
+	if string(out) != want {
+		t.Fatalf("expected %q, got %q", want, string(out))
+	}
+}
+
+func TestResolveDecodeHook(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("file-contents"), 0o600); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+
+	type target struct {
+		Token   string
+		Tags    []string
+		Headers map[string]string
+	}
+
+	var out target
+
+	err := Decode(
+		map[string]any{
+			"token": "${file:" + path + "}",
+			"tags":  []string{"${file:" + path + "}", "plain"},
+			"headers": map[string]string{
+				"auth": "${file:" + path + "}",
+			},
+		},
+		&out,
+		WithDecodeResolvers(FileResolver{}),
+	)
+	if err != nil {
+		t.Fatalf("Decode returned error: %v", err)
+	}
+
+	if out.Token != "file-contents" {
+		t.Fatalf("expected Token to resolve, got %q", out.Token)
+	}
+
+	if len(out.Tags) != 2 || out.Tags[0] != "file-contents" || out.Tags[1] != "plain" {
+		t.Fatalf("expected Tags to resolve only the placeholder entry, got %+v", out.Tags)
+	}
+
+	if out.Headers["auth"] != "file-contents" {
+		t.Fatalf("expected Headers[auth] to resolve, got %q", out.Headers["auth"])
+	}
+}
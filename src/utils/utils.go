@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/BurntSushi/toml"
 	"github.com/mitchellh/mapstructure"
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v3"
@@ -84,9 +85,41 @@ func NonNilOrDefault[T any](v *T, dflt T) T {
 	return dflt
 }
 
+// DecodeOption configures optional behavior of Decode.
+type DecodeOption func(*decodeOpts)
+
+type decodeOpts struct {
+	resolvers []ValueResolver
+	exprVars  map[string]any
+}
+
+// WithDecodeResolvers registers ValueResolvers for Decode to run against string, []string
+// and map[string]string fields, expanding `${scheme:ref}` references in place as
+// Unmarshal's WithResolvers does, except that only the first placeholder of each string is
+// looked at and bare `${VAR}` references are not expanded.
+func WithDecodeResolvers(resolvers ...ValueResolver) DecodeOption {
+	return func(o *decodeOpts) {
+		o.resolvers = append(o.resolvers, resolvers...)
+	}
+}
+
+// WithExprVars makes vars available, alongside env/now/hostname, to any `${expr: ...}`
+// field Decode evaluates - e.g. `cpuCount` for a job config computing `count` from it.
+func WithExprVars(vars map[string]any) DecodeOption {
+	return func(o *decodeOpts) {
+		o.exprVars = vars
+	}
+}
+
 // Decode is an alias to a mapstructure.NewDecoder({Squash: true}).Decode()
-// with WeaklyTypedInput set to true and MatchFunc that only compares aplhanumeric sequence in field names
-func Decode(input any, output any) error {
+// with WeaklyTypedInput set to true and MatchFunc that only compares aplhanumeric sequence in field names,
+// additionally falling back to a field's toml:"..." and hcl:",..." tags when present
+func Decode(input any, output any, opts ...DecodeOption) error {
+	var o decodeOpts
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	filter := func(r rune) rune {
 		if ('a' <= r && r <= 'z') ||
 			('A' <= r && r <= 'Z') ||
@@ -97,16 +130,36 @@ func Decode(input any, output any) error {
 		return -1
 	}
 
+	fold := func(s string) string {
+		return strings.ToLower(strings.Map(filter, s))
+	}
+
+	aliases := fieldAliases(output, fold)
+
 	matchName := func(lhs, rhs string) bool {
-		return strings.EqualFold(strings.Map(filter, lhs), strings.Map(filter, rhs))
+		if fold(lhs) == fold(rhs) {
+			return true
+		}
+
+		for _, alias := range aliases[rhs] {
+			if fold(lhs) == alias {
+				return true
+			}
+		}
+
+		return false
 	}
 
 	decoderConfig := &mapstructure.DecoderConfig{
 		Squash:           true,
 		WeaklyTypedInput: true,
 		MatchName:        matchName,
-		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
-		Result:           output,
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			exprDecodeHook(o.exprVars),
+			resolveDecodeHook(o.resolvers),
+		),
+		Result: output,
 	}
 
 	decoder, err := mapstructure.NewDecoder(decoderConfig)
@@ -139,12 +192,30 @@ func InfiniteRange[T any](ctx context.Context, input []T) chan T {
 	return result
 }
 
-func Unmarshal(input []byte, output any, format string) error {
+func Unmarshal(input []byte, output any, format string, opts ...UnmarshalOption) error {
+	var o unmarshalOpts
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	input, err := expandPlaceholders(input, o)
+	if err != nil {
+		return err
+	}
+
 	switch format {
 	case "", "json", "yaml":
 		if err := yaml.Unmarshal(input, output); err != nil {
 			return err
 		}
+	case "toml":
+		if err := toml.Unmarshal(input, output); err != nil {
+			return err
+		}
+	case "hcl":
+		if err := unmarshalHCL(input, output); err != nil {
+			return err
+		}
 	default:
 		return fmt.Errorf("unknown config format: %v", format)
 	}